@@ -0,0 +1,167 @@
+package rebed
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BoundRoot confines directory and file creation to a single root
+// directory on disk, rejecting any relative path that would escape it
+// via ".." segments, an absolute path, or traversal through a symlink
+// planted inside the root. Use Bound to construct one, or set
+// Options.Bound to have TreeWith/WriteWith/PatchWith/CreateWith use one
+// automatically.
+//
+// On Linux, paths are resolved with the openat2 RESOLVE_BENEATH syscall
+// where the kernel supports it (probed once at first use), which closes
+// the TOCTOU window a symlink swapped in mid-resolution would otherwise
+// open. Elsewhere, or on older kernels, BoundRoot falls back to a
+// best-effort path-based walk that checks each component with os.Lstat
+// and refuses to traverse any existing symlink component.
+type BoundRoot struct {
+	root string
+	dir  *os.File // O_DIRECTORY handle on root, used as the openat2 dirfd on Linux
+}
+
+// Bound creates outputPath if necessary and returns a BoundRoot rooted
+// at it.
+func Bound(outputPath string) (*BoundRoot, error) {
+	abs, err := filepath.Abs(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, folderPerm); err != nil {
+		return nil, err
+	}
+	dir, err := os.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	return &BoundRoot{root: abs, dir: dir}, nil
+}
+
+// Close releases the root directory handle that Bound opened. Bound opens
+// this handle on every platform, so Close should always be called.
+func (b *BoundRoot) Close() error {
+	if b.dir == nil {
+		return nil
+	}
+	return b.dir.Close()
+}
+
+// MkdirAll creates the directory named by the embed-relative path rel,
+// along with any missing parents, within b's root.
+func (b *BoundRoot) MkdirAll(rel string, perm fs.FileMode) error {
+	segs, err := boundSegments(rel)
+	if err != nil {
+		return err
+	}
+	if len(segs) == 0 {
+		return nil
+	}
+	if fastMkdirAll != nil {
+		if err := fastMkdirAll(b.dir, segs, perm); err != errUnsupportedFastPath {
+			return err
+		}
+	}
+	_, err = portableResolveDir(b.root, segs, true, perm)
+	return err
+}
+
+// Create creates or truncates the file named by the embed-relative path
+// rel within b's root, with the given permission. Parent directories
+// must already exist.
+func (b *BoundRoot) Create(rel string, perm fs.FileMode) (*os.File, error) {
+	segs, err := boundSegments(rel)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("rebed: empty bound path")
+	}
+	if fastCreate != nil {
+		f, err := fastCreate(b.dir, segs, perm)
+		if err != errUnsupportedFastPath {
+			return f, err
+		}
+	}
+	return portableCreate(b.root, segs, perm)
+}
+
+// fastMkdirAll and fastCreate are overridden by bound_linux.go on
+// platforms where the openat2 fast path is available. errUnsupportedFastPath
+// signals that no syscall-based fast path could be used, so the caller
+// should fall back to the portable implementation below.
+var (
+	fastMkdirAll func(dir *os.File, segs []string, perm fs.FileMode) error
+	fastCreate   func(dir *os.File, segs []string, perm fs.FileMode) (*os.File, error)
+)
+
+var errUnsupportedFastPath = fmt.Errorf("rebed: openat2 fast path unsupported")
+
+// boundSegments validates rel and splits it into path components,
+// rejecting absolute paths and any ".." segment that would climb above
+// the root.
+func boundSegments(rel string) ([]string, error) {
+	rel = sanitize(rel)
+	if path.IsAbs(rel) {
+		return nil, fmt.Errorf("rebed: bound path %q is absolute", rel)
+	}
+	clean := path.Clean(rel)
+	if clean == "." {
+		return nil, nil
+	}
+	segs := strings.Split(clean, "/")
+	for _, seg := range segs {
+		if seg == ".." {
+			return nil, fmt.Errorf("rebed: bound path %q escapes its root", rel)
+		}
+	}
+	return segs, nil
+}
+
+// portableResolveDir walks segs from root, creating missing directories
+// when create is true, and refuses to step through any existing symlink.
+func portableResolveDir(root string, segs []string, create bool, perm fs.FileMode) (string, error) {
+	cur := root
+	for _, seg := range segs {
+		next := filepath.Join(cur, seg)
+		fi, err := os.Lstat(next)
+		switch {
+		case err == nil:
+			if fi.Mode()&fs.ModeSymlink != 0 {
+				return "", fmt.Errorf("rebed: refusing to traverse symlink %q in bound output", next)
+			}
+		case os.IsNotExist(err):
+			if !create {
+				return "", err
+			}
+			if err := os.Mkdir(next, perm); err != nil && !os.IsExist(err) {
+				return "", err
+			}
+		default:
+			return "", err
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// portableCreate resolves segs[:len(segs)-1] as existing directories and
+// creates the final segment as a regular file, refusing to overwrite a
+// symlink planted at that path.
+func portableCreate(root string, segs []string, perm fs.FileMode) (*os.File, error) {
+	dir, err := portableResolveDir(root, segs[:len(segs)-1], false, folderPerm)
+	if err != nil {
+		return nil, err
+	}
+	final := filepath.Join(dir, segs[len(segs)-1])
+	if fi, err := os.Lstat(final); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		return nil, fmt.Errorf("rebed: refusing to write through symlink %q in bound output", final)
+	}
+	return os.OpenFile(final, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+}
@@ -0,0 +1,126 @@
+//go:build linux && (amd64 || arm64)
+
+package rebed
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// openat2 syscall number, shared by amd64 and arm64 under the generic
+// Linux syscall table. O_PATH is likewise the same on every architecture
+// but, for historical reasons, isn't defined by the syscall package.
+const (
+	sysOpenat2     = 437
+	oPath          = 0x200000
+	resolveBeneath = 0x08
+	atFDCWD        = -100
+)
+
+// openHow mirrors the kernel's struct open_how.
+type openHow struct {
+	Flags   uint64
+	Mode    uint64
+	Resolve uint64
+}
+
+var openat2Supported = sync.OnceValue(func() bool {
+	how := openHow{Flags: uint64(syscall.O_DIRECTORY | oPath), Resolve: resolveBeneath}
+	fd, err := rawOpenat2(atFDCWD, ".", &how)
+	if err == syscall.ENOSYS || err == syscall.EINVAL {
+		return false
+	}
+	if err == nil {
+		syscall.Close(fd)
+	}
+	return true
+})
+
+func rawOpenat2(dirfd int, path string, how *openHow) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return -1, err
+	}
+	r1, _, errno := syscall.Syscall6(sysOpenat2, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(how)), unsafe.Sizeof(*how), 0, 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(r1), nil
+}
+
+func init() {
+	fastMkdirAll = linuxMkdirAll
+	fastCreate = linuxCreate
+}
+
+func linuxMkdirAll(dir *os.File, segs []string, perm fs.FileMode) error {
+	if !openat2Supported() {
+		return errUnsupportedFastPath
+	}
+	curFd := int(dir.Fd())
+	ownedFd := -1
+	defer func() {
+		if ownedFd >= 0 {
+			syscall.Close(ownedFd)
+		}
+	}()
+	dirHow := openHow{Flags: uint64(syscall.O_DIRECTORY | oPath), Resolve: resolveBeneath}
+	for _, seg := range segs {
+		fd, err := rawOpenat2(curFd, seg, &dirHow)
+		if err == syscall.ENOENT {
+			if mkErr := syscall.Mkdirat(curFd, seg, uint32(perm.Perm())); mkErr != nil && mkErr != syscall.EEXIST {
+				return mkErr
+			}
+			fd, err = rawOpenat2(curFd, seg, &dirHow)
+		}
+		if err != nil {
+			return err
+		}
+		if ownedFd >= 0 {
+			syscall.Close(ownedFd)
+		}
+		ownedFd = fd
+		curFd = fd
+	}
+	return nil
+}
+
+func linuxCreate(dir *os.File, segs []string, perm fs.FileMode) (*os.File, error) {
+	if !openat2Supported() {
+		return nil, errUnsupportedFastPath
+	}
+	curFd := int(dir.Fd())
+	ownedFd := -1
+	defer func() {
+		if ownedFd >= 0 {
+			syscall.Close(ownedFd)
+		}
+	}()
+	dirHow := openHow{Flags: uint64(syscall.O_DIRECTORY | oPath), Resolve: resolveBeneath}
+	for _, seg := range segs[:len(segs)-1] {
+		fd, err := rawOpenat2(curFd, seg, &dirHow)
+		if err != nil {
+			return nil, err
+		}
+		if ownedFd >= 0 {
+			syscall.Close(ownedFd)
+		}
+		ownedFd = fd
+		curFd = fd
+	}
+	base := segs[len(segs)-1]
+	fileHow := openHow{
+		Flags:   uint64(syscall.O_WRONLY | syscall.O_CREAT | syscall.O_TRUNC),
+		Mode:    uint64(perm.Perm()),
+		Resolve: resolveBeneath,
+	}
+	fd, err := rawOpenat2(curFd, base, &fileHow)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), base), nil
+}
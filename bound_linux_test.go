@@ -0,0 +1,45 @@
+//go:build linux && (amd64 || arm64)
+
+package rebed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLinuxFastPath exercises linuxMkdirAll and linuxCreate directly,
+// rather than through BoundRoot, to check the openat2 RESOLVE_BENEATH fast
+// path on its own. It is skipped on kernels without openat2, where
+// BoundRoot uses the portable fallback in bound.go instead.
+func TestLinuxFastPath(t *testing.T) {
+	if !openat2Supported() {
+		t.Skip("openat2 unsupported on this kernel")
+	}
+	root := t.TempDir()
+	dir, err := os.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+
+	if err := linuxMkdirAll(dir, []string{"a", "b"}, 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(root, "a", "b"))
+	if err != nil {
+		t.Fatalf("expected directory %q to be created: %s", "a/b", err)
+	}
+	if !info.IsDir() {
+		t.Errorf("expected %q to be a directory", "a/b")
+	}
+
+	f, err := linuxCreate(dir, []string{"a", "b", "file.txt"}, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := os.Stat(filepath.Join(root, "a", "b", "file.txt")); err != nil {
+		t.Errorf("expected file %q to be created: %s", "a/b/file.txt", err)
+	}
+}
@@ -0,0 +1,63 @@
+package rebed
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// destination abstracts where TreeWith/WriteWith/PatchWith/CreateWith
+// write, so those functions can target either the plain filesystem or a
+// BoundRoot without duplicating their traversal logic.
+type destination interface {
+	MkdirAll(rel string, perm fs.FileMode) error
+	Create(rel string, perm fs.FileMode) (*os.File, error)
+	Stat(rel string) (os.FileInfo, error)
+	Close() error
+}
+
+// newDestination returns a destination for outputPath, honoring
+// opts.Bound.
+func newDestination(outputPath string, opts Options) (destination, error) {
+	if !opts.Bound {
+		return osDestination(outputPath), nil
+	}
+	return Bound(outputPath)
+}
+
+// osDestination is the unbound destination: it joins rel onto outputPath
+// with filepath.Join and performs ordinary os calls, exactly as rebed did
+// before BoundRoot existed.
+type osDestination string
+
+func (d osDestination) MkdirAll(rel string, perm fs.FileMode) error {
+	return os.MkdirAll(filepath.Join(string(d), rel), perm)
+}
+
+func (d osDestination) Create(rel string, perm fs.FileMode) (*os.File, error) {
+	return os.OpenFile(filepath.Join(string(d), rel), os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (d osDestination) Stat(rel string) (os.FileInfo, error) {
+	return os.Stat(filepath.Join(string(d), rel))
+}
+
+func (d osDestination) Close() error { return nil }
+
+// Stat resolves rel's parent directories with the portable walk, refusing
+// to step through a symlink among them, and stats the final component with
+// os.Stat, which follows a symlink at that last component.
+func (b *BoundRoot) Stat(rel string) (os.FileInfo, error) {
+	segs, err := boundSegments(rel)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		return os.Stat(b.root)
+	}
+	dir, err := portableResolveDir(b.root, segs[:len(segs)-1], false, folderPerm)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(filepath.Join(dir, segs[len(segs)-1]))
+}
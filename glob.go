@@ -0,0 +1,61 @@
+package rebed
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether name (a "/"-separated path) matches pattern,
+// a gitignore-style glob where "**" matches zero or more whole path
+// segments and any other segment is matched with path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	if len(pat) == 0 {
+		return len(name) == 0
+	}
+	if pat[0] == "**" {
+		if matchSegments(pat[1:], name) {
+			return true
+		}
+		if len(name) == 0 {
+			return false
+		}
+		return matchSegments(pat, name[1:])
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pat[0], name[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pat[1:], name[1:])
+}
+
+// globMayMatchUnder reports whether pattern could still match some path
+// that has dir as a prefix, i.e. whether it is worth descending into dir
+// while looking for matches of pattern. It is used to prune directory
+// subtrees that an Include pattern can never reach.
+func globMayMatchUnder(pattern, dir string) bool {
+	return prefixMayMatch(strings.Split(pattern, "/"), strings.Split(dir, "/"))
+}
+
+func prefixMayMatch(pat, dir []string) bool {
+	if len(dir) == 0 {
+		return true // dir is an ancestor of, or equal to, the pattern's target
+	}
+	if len(pat) == 0 {
+		return false // pattern exhausted but dir still descends further
+	}
+	if pat[0] == "**" {
+		return true // "**" can absorb the remainder of dir
+	}
+	ok, err := path.Match(pat[0], dir[0])
+	if err != nil || !ok {
+		return false
+	}
+	return prefixMayMatch(pat[1:], dir[1:])
+}
@@ -0,0 +1,92 @@
+package rebed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestEntry records the permission and modification time of a single
+// file as captured by GenerateManifest.
+type ManifestEntry struct {
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+}
+
+// Manifest maps a file's embed-relative path to its recorded metadata.
+// embed.FS erases file mode and mtime, so a Manifest is the supported way
+// to carry that metadata alongside an embed and restore it on write; see
+// GenerateManifest and WriteWithManifest.
+type Manifest map[string]ManifestEntry
+
+// GenerateManifest walks srcDir and writes a Manifest of every regular
+// file's mode and mtime to outFile as JSON, keyed by path relative to
+// srcDir. It is meant to be run at build time, before srcDir is embedded,
+// from a small go:generate program placed alongside the embed:
+//
+//	//go:generate go run ./internal/genmanifest srcDir srcDir/rebed.manifest.json
+//	//go:embed srcDir
+//	var assets embed.FS
+//
+// Because the keys are relative to srcDir, the fs.FS passed to
+// WriteWithManifest must be rooted at srcDir, e.g. fs.Sub(assets, "srcDir").
+func GenerateManifest(srcDir, outFile string) error {
+	manifest := make(Manifest)
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		manifest[sanitize(rel)] = ManifestEntry{Mode: info.Mode(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outFile, data, 0644)
+}
+
+// WriteWithManifest behaves like Write, then applies the mode and mtime
+// recorded for each file in the Manifest at manifestPath, read from fsys
+// following the //go:embed rebed.manifest.json convention.
+func WriteWithManifest(fsys fs.FS, outputPath, manifestPath string) error {
+	if err := Write(fsys, outputPath); err != nil {
+		return err
+	}
+	f, err := fsys.Open(manifestPath)
+	if err != nil {
+		return fmt.Errorf("opening manifest %v: %v", manifestPath, err)
+	}
+	defer f.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return fmt.Errorf("decoding manifest %v: %v", manifestPath, err)
+	}
+	for rel, entry := range manifest {
+		fullpath := filepath.Join(outputPath, sanitize(rel))
+		if err := os.Chmod(fullpath, entry.Mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(fullpath, entry.ModTime, entry.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
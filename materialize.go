@@ -0,0 +1,156 @@
+package rebed
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// TreeWith behaves like Tree but only materializes folders selected by opts.
+func TreeWith(fsys fs.FS, outputPath string, opts Options) error {
+	dest, err := newDestination(outputPath, opts)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	return WalkWith(fsys, ".", opts, func(dirpath string, de fs.DirEntry) error {
+		rel := sanitize(filepath.Join(dirpath, de.Name()))
+		if de.IsDir() {
+			return dest.MkdirAll(rel, opts.dirPerm())
+		}
+		return nil
+	})
+}
+
+// WriteWith behaves like Write but only materializes entries selected by opts.
+func WriteWith(fsys fs.FS, outputPath string, opts Options) error {
+	dest, err := newDestination(outputPath, opts)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	return WalkWith(fsys, ".", opts, func(dirpath string, de fs.DirEntry) error {
+		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
+		if de.IsDir() {
+			return dest.MkdirAll(embedPath, opts.dirPerm())
+		}
+		return embedCopyToDest(fsys, embedPath, dest, opts.filePerm())
+	})
+}
+
+// PatchWith behaves like Patch but only materializes entries selected by opts.
+func PatchWith(fsys fs.FS, outputPath string, opts Options) error {
+	dest, err := newDestination(outputPath, opts)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	return WalkWith(fsys, ".", opts, func(dirpath string, de fs.DirEntry) error {
+		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
+		if de.IsDir() {
+			return dest.MkdirAll(embedPath, opts.dirPerm())
+		}
+		_, err := dest.Stat(embedPath)
+		if os.IsNotExist(err) {
+			err = embedCopyToDest(fsys, embedPath, dest, opts.filePerm())
+		}
+		return err
+	})
+}
+
+// CreateWith behaves like Create but only considers entries selected by opts.
+func CreateWith(fsys fs.FS, outputPath string, opts Options) error {
+	dest, err := newDestination(outputPath, opts)
+	if err != nil {
+		return err
+	}
+	err = WalkWith(fsys, ".", opts, func(dirpath string, de fs.DirEntry) error {
+		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
+		if de.IsDir() {
+			return nil
+		}
+		_, err := dest.Stat(embedPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return ErrExist
+	})
+	dest.Close()
+	if err != nil {
+		return err
+	}
+	return PatchWith(fsys, outputPath, opts)
+}
+
+// embedCopyToDest copies an embedded file's contents to dest. embedPath
+// is expected to name a regular file; on a filesystem that exposes real
+// symlinks (e.g. os.DirFS) it may instead name a symlink that resolves
+// to a directory, which embedCopyToDest rejects before creating
+// anything in dest, rather than leaving a truncated or corrupt entry.
+func embedCopyToDest(fsys fs.FS, embedPath string, dest destination, perm fs.FileMode) error {
+	fi, err := fsys.Open(embedPath)
+	if err != nil {
+		return fmt.Errorf("opening embedded file %v: %v", embedPath, err)
+	}
+	defer fi.Close()
+	if info, err := fi.Stat(); err == nil && info.IsDir() {
+		return fmt.Errorf("rebed: %q is a symlink to a directory, which is not supported", embedPath)
+	}
+	fo, err := dest.Create(embedPath, perm)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+	_, err = io.Copy(fo, fi)
+	return err
+}
+
+// WalkWith behaves like Walk but prunes directories and filters files
+// according to opts.Include, opts.Exclude and opts.FollowSymlinks. A
+// directory whose entire subtree is excluded is never descended into,
+// so large filesystems can be partially materialized efficiently.
+func WalkWith(fsys fs.FS, startPath string, opts Options, f func(path string, de fs.DirEntry) error) error {
+	folders := make([]string, 0)
+	visit := func(dirpath string, de fs.DirEntry) error {
+		fullpath := sanitize(filepath.Join(dirpath, de.Name()))
+		if opts.skipSymlink(de) {
+			return nil
+		}
+		if de.IsDir() {
+			if !opts.dirMayMatch(fullpath) {
+				return nil
+			}
+			folders = append(folders, fullpath)
+			return f(dirpath, de)
+		}
+		if !opts.fileMatches(fullpath) {
+			return nil
+		}
+		return f(dirpath, de)
+	}
+	err := WalkDir(fsys, startPath, visit)
+	if err != nil {
+		if len(folders) == 0 {
+			return fmt.Errorf("no folder found: %v", err)
+		}
+		return err
+	}
+	n := len(folders)
+	for n != 0 {
+		for i := 0; i < n; i++ {
+			err = WalkDir(fsys, folders[i], visit)
+			if err != nil {
+				return err
+			}
+		}
+		var newFolders int = len(folders) - n
+		folders = folders[n : n+newFolders]
+		n = len(folders)
+	}
+	return nil
+}
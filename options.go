@@ -0,0 +1,117 @@
+package rebed
+
+import "io/fs"
+
+// Options controls how TreeWith, WriteWith, PatchWith and CreateWith select
+// and traverse entries. The zero value matches every entry except
+// symlinks, which are skipped unless FollowSymlinks is set; Tree, Write,
+// Patch and Create call through with FollowSymlinks enabled so their
+// behavior is unchanged.
+type Options struct {
+	// Include, if non-empty, restricts materialization to paths matching
+	// at least one of these gitignore-style glob patterns. A file must
+	// match an Include pattern to be materialized; a directory is created
+	// when some Include pattern could still match a path beneath it, and
+	// pruned otherwise. Patterns are evaluated
+	// against the path relative to the fs.FS root, using "/" as the
+	// separator regardless of host OS; "**" matches zero or more path
+	// segments, e.g. "assets/**/*.png".
+	Include []string
+
+	// Exclude skips any path matching one of these patterns. A directory
+	// matching an Exclude pattern has its entire subtree pruned during
+	// traversal rather than being filtered out entry by entry.
+	Exclude []string
+
+	// FollowSymlinks controls whether symlink entries are traversed.
+	// fs.FS implementations that don't expose symlinks (embed.FS,
+	// fstest.MapFS) are unaffected; this only matters for filesystems
+	// such as those returned by os.DirFS. A followed symlink pointing at
+	// a file is copied normally; one pointing at a directory is not
+	// supported and causes WriteWith/PatchWith/CreateWith to return an
+	// error for that entry rather than writing a partial copy.
+	FollowSymlinks bool
+
+	// Bound confines materialization to the output directory via a
+	// BoundRoot, rejecting entries whose path would otherwise escape it
+	// through ".." segments, an absolute path, or a symlink planted in
+	// the output tree. See Bound for the guarantees this provides.
+	Bound bool
+
+	// DefaultFileMode overrides the permission bits used when creating
+	// files. The zero value keeps the previous behavior: files are
+	// created with os.Create's default of 0666, subject to umask.
+	DefaultFileMode fs.FileMode
+
+	// DirMode overrides the permission used when creating directories.
+	// The zero value keeps the previous hard-coded default of 0755.
+	DirMode fs.FileMode
+}
+
+// dirPerm returns the permission to create directories with.
+func (o Options) dirPerm() fs.FileMode {
+	if o.DirMode != 0 {
+		return o.DirMode
+	}
+	return folderPerm
+}
+
+// filePerm returns the permission to create files with.
+func (o Options) filePerm() fs.FileMode {
+	if o.DefaultFileMode != 0 {
+		return o.DefaultFileMode
+	}
+	return 0666
+}
+
+// excluded reports whether path matches one of o.Exclude.
+func (o Options) excluded(path string) bool {
+	for _, pat := range o.Exclude {
+		if matchGlob(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// included reports whether path matches o.Include, or o.Include is empty.
+func (o Options) included(path string) bool {
+	if len(o.Include) == 0 {
+		return true
+	}
+	for _, pat := range o.Include {
+		if matchGlob(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileMatches reports whether a file at path should be materialized.
+func (o Options) fileMatches(path string) bool {
+	return o.included(path) && !o.excluded(path)
+}
+
+// dirMayMatch reports whether path should be descended into: it must not
+// be excluded outright, and at least one Include pattern must still be
+// able to match something underneath it.
+func (o Options) dirMayMatch(path string) bool {
+	if o.excluded(path) {
+		return false
+	}
+	if len(o.Include) == 0 {
+		return true
+	}
+	for _, pat := range o.Include {
+		if globMayMatchUnder(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipSymlink reports whether de should be skipped because it is a
+// symlink and o.FollowSymlinks is false.
+func (o Options) skipSymlink(de fs.DirEntry) bool {
+	return !o.FollowSymlinks && de.Type()&fs.ModeSymlink != 0
+}
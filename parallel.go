@@ -0,0 +1,118 @@
+package rebed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WriteParallel behaves like Write but copies files using n worker
+// goroutines instead of one at a time, which matters once an embed
+// contains thousands of assets. A single goroutine walks fsys, creates
+// each directory before dispatching the files in it as jobs on a channel,
+// and records created directories in a sync.Map so os.MkdirAll runs once
+// per directory.
+//
+// The first error encountered, from either the walk or a worker, cancels
+// the remaining work and is returned; files already copied by other
+// workers at that point are left on disk, so the output directory may
+// contain a partial copy of fsys after a non-nil return.
+func WriteParallel(fsys fs.FS, outputPath string, n int) error {
+	if n < 1 {
+		n = 1
+	}
+
+	type job struct{ embedPath, fullpath string }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var madeDirs sync.Map // fullpath -> struct{}{}, dedupes os.MkdirAll calls
+	ensureDir := func(fullpath string) error {
+		if _, loaded := madeDirs.LoadOrStore(fullpath, struct{}{}); loaded {
+			return nil
+		}
+		return os.MkdirAll(fullpath, folderPerm)
+	}
+
+	jobs := make(chan job)
+	var firstErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < n; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				if err := copyEmbeddedFile(fsys, j.embedPath, j.fullpath); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	walkErr := Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
+		fullpath := filepath.Join(outputPath, embedPath)
+		if de.IsDir() {
+			return ensureDir(fullpath)
+		}
+		if err := ensureDir(filepath.Dir(fullpath)); err != nil {
+			return err
+		}
+		select {
+		case jobs <- job{embedPath, fullpath}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	workers.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if walkErr != nil && walkErr != context.Canceled {
+		return walkErr
+	}
+	return nil
+}
+
+// copyEmbeddedFile copies an embedded file's contents to a file on the
+// host machine, creating or truncating it as needed. embedPath is
+// expected to name a regular file; on a filesystem that exposes real
+// symlinks (e.g. os.DirFS) it may instead name a symlink that resolves
+// to a directory, which copyEmbeddedFile rejects before creating
+// anything at fullpath, rather than leaving a truncated or corrupt file.
+func copyEmbeddedFile(fsys fs.FS, embedPath, fullpath string) error {
+	fi, err := fsys.Open(embedPath)
+	if err != nil {
+		return fmt.Errorf("opening embedded file %v: %v", embedPath, err)
+	}
+	defer fi.Close()
+	if info, err := fi.Stat(); err == nil && info.IsDir() {
+		return fmt.Errorf("rebed: %q is a symlink to a directory, which is not supported", embedPath)
+	}
+	fo, err := os.Create(fullpath)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+	_, err = io.Copy(fo, fi)
+	return err
+}
@@ -9,12 +9,17 @@
 //
 // It also provides basic directory walking functionality for
 // the embed.FS type.
+//
+// Although the name and original motivation of this package
+// revolve around embed.FS, every exported function accepts the
+// more general fs.FS interface. embed.FS, fstest.MapFS, os.DirFS
+// and any other fs.FS implementation may be passed directly since
+// they already satisfy the interface; no adapter or conversion is
+// required to keep existing embed.FS call sites compiling.
 package rebed
 
 import (
-	"embed"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -29,21 +34,17 @@ const folderPerm os.FileMode = 0755
 // a file conflict in filesystem creation
 var ErrExist error = os.ErrExist
 
-// Tree creates the target filesystem folder structure.
-func Tree(fsys embed.FS, outputPath string) error {
-	return Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
-		fullpath := filepath.Join(outputPath, dirpath, de.Name())
-		if de.IsDir() {
-			return os.MkdirAll(fullpath, folderPerm)
-		}
-		return nil
-	})
+// Tree creates the target filesystem folder structure. Tree only
+// creates directories, so a symlink entry, whether it names a file or
+// a directory, is never itself created as one.
+func Tree(fsys fs.FS, outputPath string) error {
+	return TreeWith(fsys, outputPath, Options{FollowSymlinks: true})
 }
 
 // Touch creates the target filesystem folder structure in the binary's
 // current working directory with empty files. Does not modify
 // already existing files.
-func Touch(fsys embed.FS, outputPath string) error {
+func Touch(fsys fs.FS, outputPath string) error {
 	return Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
 		fullpath := filepath.Join(outputPath, dirpath, de.Name())
 		if de.IsDir() {
@@ -60,60 +61,33 @@ func Touch(fsys embed.FS, outputPath string) error {
 
 // Write overwrites files of same path/name
 // in binaries current working directory or
-// creates new ones if not exist.
-func Write(fsys embed.FS, outputPath string) error {
-	return Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
-		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
-		fullpath := filepath.Join(outputPath, embedPath)
-		if de.IsDir() {
-			return os.MkdirAll(fullpath, folderPerm)
-		}
-		return embedCopyToFile(fsys, embedPath, fullpath)
-	})
+// creates new ones if not exist. A symlink entry pointing at a file is
+// followed and its contents copied; a symlink pointing at a directory
+// is not supported and returns an error rather than writing a partial
+// or corrupt entry.
+func Write(fsys fs.FS, outputPath string) error {
+	return WriteWith(fsys, outputPath, Options{FollowSymlinks: true})
 }
 
 // Patch creates files which are missing in
-// FS filesystem. Does not modify existing files
-func Patch(fsys embed.FS, outputPath string) error {
-	return Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
-		embedPath := sanitize(filepath.Join(dirpath, de.Name()))
-		fullpath := filepath.Join(outputPath, embedPath)
-		if de.IsDir() {
-			return os.MkdirAll(fullpath, folderPerm)
-		}
-		_, err := os.Stat(fullpath)
-		if os.IsNotExist(err) {
-			err = embedCopyToFile(fsys, embedPath, fullpath)
-		}
-		return err
-	})
+// FS filesystem. Does not modify existing files. A symlink entry
+// pointing at a file is followed and its contents copied; a symlink
+// pointing at a directory is not supported and returns an error rather
+// than writing a partial or corrupt entry.
+func Patch(fsys fs.FS, outputPath string) error {
+	return PatchWith(fsys, outputPath, Options{FollowSymlinks: true})
 }
 
 // Create attempts to recreate filesystem. It first checks that
 // there be no matching files present and returns an error
 // if there is an existing file conflict in outputPath.
 //
-// Folders are not considered to conflict.
-func Create(fsys embed.FS, outputPath string) error {
-	err := Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
-		embedPath := filepath.Join(dirpath, de.Name())
-		fullpath := filepath.Join(outputPath, embedPath)
-		if de.IsDir() {
-			return nil
-		}
-		_, err := os.Stat(fullpath)
-		if os.IsNotExist(err) {
-			return nil
-		}
-		if err != nil {
-			return err
-		}
-		return ErrExist
-	})
-	if err != nil {
-		return err
-	}
-	return Patch(fsys, outputPath)
+// Folders are not considered to conflict. A symlink entry pointing at a
+// file is followed and its contents copied; a symlink pointing at a
+// directory is not supported and returns an error rather than writing a
+// partial or corrupt entry.
+func Create(fsys fs.FS, outputPath string) error {
+	return CreateWith(fsys, outputPath, Options{FollowSymlinks: true})
 }
 
 // Walk expects a relative path within fsys.
@@ -123,7 +97,7 @@ func Create(fsys embed.FS, outputPath string) error {
 // "." as startPath will scan all files and folders.
 //
 // Any error returned by f will cause Walk to return said error immediately.
-func Walk(fsys embed.FS, startPath string, f func(path string, de fs.DirEntry) error) error {
+func Walk(fsys fs.FS, startPath string, f func(path string, de fs.DirEntry) error) error {
 	folders := make([]string, 0) // buffer of folders to process
 	err := WalkDir(fsys, startPath, func(dirpath string, de fs.DirEntry) error {
 		if de.IsDir() {
@@ -163,9 +137,12 @@ func Walk(fsys embed.FS, startPath string, f func(path string, de fs.DirEntry) e
 // WalkDir applies f to every file/folder in embedded directory fsys.
 //
 // f's first argument is the relative/absolute path to directory being scanned.
-func WalkDir(fsys embed.FS, startPath string, f func(path string, de fs.DirEntry) error) error {
+//
+// fsys need not implement fs.ReadDirFS: if it doesn't, WalkDir falls back
+// to opening the directory and reading its entries through fs.ReadDir.
+func WalkDir(fsys fs.FS, startPath string, f func(path string, de fs.DirEntry) error) error {
 	startPath = sanitize(startPath)
-	items, err := fsys.ReadDir(startPath)
+	items, err := fs.ReadDir(fsys, startPath)
 	if err != nil {
 		return err
 	}
@@ -177,22 +154,6 @@ func WalkDir(fsys embed.FS, startPath string, f func(path string, de fs.DirEntry
 	return nil
 }
 
-// embedCopyToFile copies an embedded file's contents
-// to a file on the host machine.
-func embedCopyToFile(fsys embed.FS, embedPath, path string) error {
-	embedPath = sanitize(embedPath)
-	fi, err := fsys.Open(embedPath)
-	if err != nil {
-		return fmt.Errorf("opening embedded file %v: %v", embedPath, err)
-	}
-	fo, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	_, err = io.Copy(fo, fi)
-	return err
-}
-
 // sanitize converts windows representation of path to embed.FS representation
 func sanitize(embedPath string) string {
 	return strings.ReplaceAll(embedPath, "\\", "/")
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/chengziqing/rebed"
 )
@@ -102,7 +104,322 @@ func TestWalkDirError(t *testing.T) {
 	}
 }
 
-func testFileCreation(rebedder func(embed.FS, string) error, t *testing.T) {
+// TestMapFS exercises rebed against a synthetic fstest.MapFS rather than
+// an embed.FS, verifying the package works against any fs.FS implementation.
+func TestMapFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"dir/a.txt":     {Data: []byte("a")},
+		"dir/sub/b.txt": {Data: []byte("b")},
+		"dir/sub/c.txt": {Data: []byte("c")},
+	}
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	err := rebed.Write(mapFS, tDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range mapFS {
+		_, err := os.Stat(filepath.Join(tDir, name))
+		if err != nil {
+			t.Errorf("expected %q to be written: %s", name, err)
+		}
+	}
+}
+
+// TestWriteWithFilter checks that an Include pattern restricts which
+// entries are materialized and that directory subtrees it cannot reach
+// are pruned.
+func TestWriteWithFilter(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"assets/a.png":     {Data: []byte("a")},
+		"assets/b.txt":     {Data: []byte("b")},
+		"assets/sub/c.png": {Data: []byte("c")},
+		"internal/d.go":    {Data: []byte("d")},
+	}
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	err := rebed.WriteWith(mapFS, tDir, rebed.Options{Include: []string{"assets/**/*.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantExists := []string{"assets/a.png", "assets/sub/c.png"}
+	for _, name := range wantExists {
+		if _, err := os.Stat(filepath.Join(tDir, name)); err != nil {
+			t.Errorf("expected %q to be written: %s", name, err)
+		}
+	}
+	wantMissing := []string{"assets/b.txt", "internal/d.go"}
+	for _, name := range wantMissing {
+		if _, err := os.Stat(filepath.Join(tDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be excluded, got err=%v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "internal")); !os.IsNotExist(err) {
+		t.Errorf("expected excluded directory %q to be pruned entirely, got err=%v", "internal", err)
+	}
+}
+
+// TestWriteFollowsSymlinks pins the default symlink behavior of the plain
+// Write function against an os.DirFS source: symlink entries must be
+// materialized, not silently skipped.
+func TestWriteFollowsSymlinks(t *testing.T) {
+	srcDir := filepath.Join(testDir, t.Name()+"_src")
+	setup(srcDir, t)
+	defer os.RemoveAll(srcDir)
+	if err := os.WriteFile(filepath.Join(srcDir, "real.txt"), []byte("real"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real.txt", filepath.Join(srcDir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	if err := rebed.Write(os.DirFS(srcDir), tDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "link.txt")); err != nil {
+		t.Errorf("expected symlink entry %q to be followed and written: %s", "link.txt", err)
+	}
+}
+
+// TestWriteRejectsSymlinkToDirectory checks that a symlink pointing at a
+// directory fails cleanly, without leaving a corrupt empty file where a
+// directory should be.
+func TestWriteRejectsSymlinkToDirectory(t *testing.T) {
+	srcDir := filepath.Join(testDir, t.Name()+"_src")
+	setup(srcDir, t)
+	defer os.RemoveAll(srcDir)
+	if err := os.MkdirAll(filepath.Join(srcDir, "realdir"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("realdir", filepath.Join(srcDir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	if err := rebed.Write(os.DirFS(srcDir), tDir); err == nil {
+		t.Fatal("expected error for symlink pointing at a directory")
+	}
+	if info, statErr := os.Lstat(filepath.Join(tDir, "linkdir")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no entry at %q, got %+v (err=%v)", "linkdir", info, statErr)
+	}
+}
+
+// TestSnapshotDiff writes an embed to disk, edits one of the files and
+// adds another, then checks that Snapshot+Diff reports the edited file as
+// modified and the new file as added.
+func TestSnapshotDiff(t *testing.T) {
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	if err := rebed.Write(testFS, tDir); err != nil {
+		t.Fatal(err)
+	}
+	var firstFile string
+	err := rebed.Walk(testFS, ".", func(path string, de fs.DirEntry) error {
+		if !de.IsDir() && firstFile == "" {
+			firstFile = filepath.Join(path, de.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstFile == "" {
+		t.Fatal("embedded test tree has no files")
+	}
+	if err := os.WriteFile(filepath.Join(tDir, firstFile), []byte("edited by end user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tDir, "extra.txt"), []byte("new file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	live, err := rebed.Snapshot(tDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := rebed.Diff(testFS, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sawModified, sawAdded bool
+	for _, c := range changes {
+		switch {
+		case c.Path == firstFile && c.Kind == rebed.Modified:
+			sawModified = true
+		case c.Path == "extra.txt" && c.Kind == rebed.Added:
+			sawAdded = true
+		}
+	}
+	if !sawModified {
+		t.Errorf("expected %q to be reported as modified, got %+v", firstFile, changes)
+	}
+	if !sawAdded {
+		t.Errorf("expected extra.txt to be reported as added, got %+v", changes)
+	}
+}
+
+// TestBoundRejectsDotDot checks that Options.Bound refuses to materialize
+// an embed entry whose path climbs out of the output directory.
+func TestBoundRejectsDotDot(t *testing.T) {
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	mapFS := fstest.MapFS{
+		"../../evil.txt": {Data: []byte("pwned")},
+	}
+	err := rebed.WriteWith(mapFS, tDir, rebed.Options{Bound: true})
+	if err == nil {
+		t.Fatal("expected error for path escaping bound root")
+	}
+	if _, statErr := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(tDir)), "evil.txt")); !os.IsNotExist(statErr) {
+		t.Fatalf("escape path should not have been created, stat err=%v", statErr)
+	}
+}
+
+// TestBoundRefusesSymlinkEscape checks that Options.Bound refuses to
+// follow a symlink planted inside the output directory to write outside it.
+func TestBoundRefusesSymlinkEscape(t *testing.T) {
+	tDir := filepath.Join(testDir, t.Name())
+	outside := filepath.Join(testDir, t.Name()+"_outside")
+	setup(tDir, t)
+	setup(outside, t)
+	defer os.RemoveAll(tDir)
+	defer os.RemoveAll(outside)
+	absOutside, absErr := filepath.Abs(outside)
+	if absErr != nil {
+		t.Fatal(absErr)
+	}
+	if err := os.Symlink(absOutside, filepath.Join(tDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	mapFS := fstest.MapFS{
+		"link/evil.txt": {Data: []byte("pwned")},
+	}
+	err := rebed.WriteWith(mapFS, tDir, rebed.Options{Bound: true})
+	if err == nil {
+		t.Fatal("expected error when traversing symlink inside bound root")
+	}
+	if _, statErr := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(statErr) {
+		t.Fatalf("file should not have escaped through symlink, stat err=%v", statErr)
+	}
+}
+
+// TestGenerateManifestWriteWithManifest checks that a manifest generated
+// from a source tree restores the recorded file mode and mtime when
+// applied by WriteWithManifest.
+func TestGenerateManifestWriteWithManifest(t *testing.T) {
+	srcDir := filepath.Join(testDir, t.Name()+"_src")
+	setup(srcDir, t)
+	defer os.RemoveAll(srcDir)
+	scriptPath := filepath.Join(srcDir, "run.sh")
+	if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\necho hi\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(scriptPath, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	manifestPath := filepath.Join(srcDir, "rebed.manifest.json")
+	if err := rebed.GenerateManifest(srcDir, manifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	mapFS := fstest.MapFS{}
+	scriptData, err := os.ReadFile(scriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapFS["run.sh"] = &fstest.MapFile{Data: scriptData}
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapFS["rebed.manifest.json"] = &fstest.MapFile{Data: manifestData}
+
+	outDir := filepath.Join(testDir, t.Name()+"_out")
+	setup(outDir, t)
+	defer os.RemoveAll(outDir)
+	if err := rebed.WriteWithManifest(mapFS, outDir, "rebed.manifest.json"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(outDir, "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("expected mtime %v, got %v", mtime, info.ModTime())
+	}
+}
+
+// TestWriteParallel checks that WriteParallel materializes the same
+// files as Write.
+func TestWriteParallel(t *testing.T) {
+	tDir := filepath.Join(testDir, t.Name())
+	setup(tDir, t)
+	defer os.RemoveAll(tDir)
+	if err := rebed.WriteParallel(testFS, tDir, 4); err != nil {
+		t.Fatal(err)
+	}
+	err := rebed.Walk(testFS, ".", func(path string, de fs.DirEntry) error {
+		pathToCreated := filepath.Join(tDir, path, de.Name())
+		info, err := os.Stat(pathToCreated)
+		if err != nil {
+			return err
+		}
+		if de.IsDir() != info.IsDir() {
+			t.Errorf("expected folder/file got file/folder %q", pathToCreated)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// syntheticFS returns a fstest.MapFS with n small files spread across a
+// handful of directories, used to benchmark sequential vs parallel writes.
+func syntheticFS(n int) fstest.MapFS {
+	fsys := make(fstest.MapFS, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("dir%d/file%d.txt", i%16, i)
+		fsys[name] = &fstest.MapFile{Data: []byte("synthetic content")}
+	}
+	return fsys
+}
+
+func BenchmarkWrite(b *testing.B) {
+	fsys := syntheticFS(10000)
+	tDir := filepath.Join(testDir, "BenchmarkWrite")
+	for i := 0; i < b.N; i++ {
+		os.RemoveAll(tDir)
+		if err := rebed.Write(fsys, tDir); err != nil {
+			b.Fatal(err)
+		}
+	}
+	os.RemoveAll(tDir)
+}
+
+func BenchmarkWriteParallel(b *testing.B) {
+	fsys := syntheticFS(10000)
+	tDir := filepath.Join(testDir, "BenchmarkWriteParallel")
+	for i := 0; i < b.N; i++ {
+		os.RemoveAll(tDir)
+		if err := rebed.WriteParallel(fsys, tDir, 8); err != nil {
+			b.Fatal(err)
+		}
+	}
+	os.RemoveAll(tDir)
+}
+
+func testFileCreation(rebedder func(fs.FS, string) error, t *testing.T) {
 	tDir := filepath.Join(testDir, t.Name())
 	setup(tDir, t)
 	defer os.RemoveAll(tDir)
@@ -0,0 +1,147 @@
+package rebed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing/fstest"
+)
+
+// Snapshot walks the on-disk directory tree rooted at root and returns it
+// as an in-memory fs.FS, mirroring file content, permissions and
+// modification times. This lets edits end users make to a Write'd tree be
+// read back and compared against the original embed, closing the loop
+// described in the package doc.
+func Snapshot(root string) (fs.FS, error) {
+	m := make(fstest.MapFS)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = sanitize(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			m[rel] = &fstest.MapFile{Mode: fs.ModeDir | info.Mode().Perm()}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		m[rel] = &fstest.MapFile{Data: data, Mode: info.Mode(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ChangeKind classifies how a file differs between the embedded baseline
+// and a live filesystem in a Change.
+type ChangeKind int
+
+const (
+	// Modified indicates the file exists in both trees with different content.
+	Modified ChangeKind = iota
+	// Added indicates the file exists only in the live filesystem.
+	Added
+	// Removed indicates the file exists only in the embedded filesystem.
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Modified:
+		return "modified"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a single file's difference between an embedded
+// filesystem and a live one, as reported by Diff.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	// EmbeddedHash and LiveHash are hex-encoded SHA-256 hashes of the
+	// file's content in each tree. The hash for the tree the file is
+	// absent from is the empty string.
+	EmbeddedHash string
+	LiveHash     string
+}
+
+// Diff compares every file in embedded against live and reports files
+// that were added, removed or modified, along with their content hashes.
+// live is typically the result of a prior Snapshot of a directory that
+// was originally populated with Write.
+func Diff(embedded, live fs.FS) ([]Change, error) {
+	embeddedHashes, err := hashTree(embedded)
+	if err != nil {
+		return nil, err
+	}
+	liveHashes, err := hashTree(live)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for path, embeddedHash := range embeddedHashes {
+		liveHash, ok := liveHashes[path]
+		if !ok {
+			changes = append(changes, Change{Path: path, Kind: Removed, EmbeddedHash: embeddedHash})
+			continue
+		}
+		if liveHash != embeddedHash {
+			changes = append(changes, Change{Path: path, Kind: Modified, EmbeddedHash: embeddedHash, LiveHash: liveHash})
+		}
+	}
+	for path, liveHash := range liveHashes {
+		if _, ok := embeddedHashes[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: Added, LiveHash: liveHash})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// hashTree returns a map of every regular file in fsys, keyed by its
+// embed-relative path, to the hex-encoded SHA-256 hash of its content.
+func hashTree(fsys fs.FS) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := Walk(fsys, ".", func(dirpath string, de fs.DirEntry) error {
+		if de.IsDir() {
+			return nil
+		}
+		path := sanitize(filepath.Join(dirpath, de.Name()))
+		f, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		hashes[path] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	return hashes, err
+}